@@ -1,164 +1,20 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"math/big"
 	"math/bits"
-	"net"
+	"net/netip"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
-)
-
-var ipv6 = false
-
-var dottedQuad = regexp.MustCompile(`(\d{1,3}).(\d{1,3}).(\d{1,3}).(\d{1,3})`)
-
-func parsePrefixLength(input string) (net.IPMask, error) {
-	if len(input) < 1 {
-		return nil, fmt.Errorf("invalid prefix length")
-	}
-
-	n, err := strconv.Atoi(input[1:])
-	if err != nil {
-		return nil, fmt.Errorf("invalid prefix length")
-	}
-
-	if n < 0 || n > 128 {
-		return nil, fmt.Errorf("invalid prefix length (must be between 0 and 128)")
-	}
-
-	if n > 32 {
-		ipv6 = true
-	}
-
-	if ipv6 {
-		return net.CIDRMask(n, 128), nil
-	} else {
-		return net.CIDRMask(n, 32), nil
-	}
-}
-
-func interpretMask(n uint32) (net.IPMask, error) {
-	ones := bits.OnesCount32(n)
-
-	if n>>(32-ones) == (1<<ones)-1 {
-		// netmask
-		return net.CIDRMask(ones, 32), nil
-	} else if n == (1<<ones)-1 {
-		// inverse mask
-		return net.CIDRMask(32-ones, 32), nil
-	} else {
-		return nil, fmt.Errorf("invalid netmask or inverse mask")
-	}
-}
-
-func getNum(s string) uint64 {
-	n, err := strconv.ParseUint(s, 10, 32)
-	if err != nil {
-		panic(err)
-	}
-
-	return n
-}
-
-func parseMask(input string) (net.IPMask, error) {
-	match := dottedQuad.FindStringSubmatch(input)
-
-	if len(match) == 0 {
-		return nil, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
-	}
-
-	n1 := getNum(match[1])
-	n2 := getNum(match[2])
-	n3 := getNum(match[3])
-	n4 := getNum(match[4])
-
-	if n1 > 255 || n2 > 255 || n3 > 255 || n4 > 255 {
-		return nil, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
-	}
-
-	n := uint32(n1<<24 | n2<<16 | n3<<8 | n4)
-
-	mask, err := interpretMask(n)
-	if err != nil {
-		return nil, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
-	}
-
-	return mask, nil
-}
-
-func parseHex(input string) (net.IPMask, error) {
-	if len(input) != 10 {
-		return nil, fmt.Errorf("%s is not a valid netmask or inverse mask (hex values need 8 chars)", input)
-	}
-
-	n, err := strconv.ParseUint(input[2:], 16, 32)
-	if err != nil {
-		return nil, fmt.Errorf("%s is not a valid netmask or inverse mask: %w", input, err)
-	}
-
-	mask, err := interpretMask(uint32(n))
-	if err != nil {
-		return nil, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
-	}
-
-	return mask, nil
-}
-
-func prefix(mask net.IPMask) string {
-	ones, _ := mask.Size()
 
-	return fmt.Sprintf("/%d", ones)
-}
-
-func netmask(mask net.IPMask) string {
-	ones, _ := mask.Size()
-
-	n := ((1 << ones) - 1) << (32 - ones)
-
-	return fmt.Sprintf("%d.%d.%d.%d", (n>>24)&0xff, (n>>16)&0xff, (n>>8)&0xff, n&0xff)
-}
-
-func inverse(mask net.IPMask) string {
-	ones, _ := mask.Size()
-
-	n := (1 << (32 - ones)) - 1
-
-	return fmt.Sprintf("%d.%d.%d.%d", (n>>24)&0xff, (n>>16)&0xff, (n>>8)&0xff, n&0xff)
-}
-
-func b(n int64) *big.Int {
-	return big.NewInt(n)
-}
-
-func max(x, y *big.Int) *big.Int {
-	if x.Cmp(y) == -1 {
-		return y
-	} else {
-		return x
-	}
-}
-
-func total(mask net.IPMask) *big.Int {
-	ones, bits := mask.Size()
-
-	return new(big.Int).Exp(b(2), b(int64(bits-ones)), nil)
-}
-
-func usable(mask net.IPMask) *big.Int {
-	n := total(mask)
-
-	if ipv6 {
-		return n
-	}
-
-	n.Sub(n, b(2))
-	return max(n, b(0))
-}
+	"github.com/davidbalbert/ipmask/pkg/ipmask"
+)
 
 func reverse(s string) string {
 	runes := []rune(s)
@@ -194,36 +50,48 @@ func commas(n *big.Int) string {
 	return reverse(strings.Join(chunked, ","))
 }
 
-func ipToBigInt(ip net.IP) *big.Int {
-	var bytes []byte
-
-	ip4 := ip.To4()
+// expand6 renders a in the fully expanded 8-group colon-hex form,
+// e.g. "fe80::1" becomes "fe80:0000:0000:0000:0000:0000:0000:0001".
+func expand6(a netip.Addr) string {
+	b := a.As16()
+	groups := make([]string, 8)
 
-	if ip4 != nil {
-		bytes = []byte(ip4)
-	} else {
-		bytes = []byte(ip)
+	for i := range groups {
+		groups[i] = fmt.Sprintf("%02x%02x", b[i*2], b[i*2+1])
 	}
 
-	return new(big.Int).SetBytes(bytes)
+	return strings.Join(groups, ":")
 }
 
-func bigIntToIP(n *big.Int) net.IP {
-	var bytes []byte
+// classificationLine formats r's classification and reverse-DNS
+// fields for display: a single PTR name when r names a specific
+// address, or the covering PTR zone(s) for a bare mask/prefix.
+func classificationLine(r ipmask.Result) (classification, ptr string) {
+	addr := r.Addr
+	if !r.HasAddr() {
+		addr = r.Network()
+	}
+
+	labels := ipmask.Classify(addr).Labels()
+	if len(labels) == 0 {
+		classification = "Global Unicast"
+	} else {
+		classification = strings.Join(labels, ", ")
+	}
 
-	if ipv6 {
-		bytes = make([]byte, 16)
+	if r.HasAddr() {
+		ptr = ipmask.PTR(r.Addr)
 	} else {
-		bytes = make([]byte, 4)
+		ptr = strings.Join(ipmask.PTRZone(r.Prefix), ", ")
 	}
 
-	return net.IP(n.FillBytes(bytes))
+	return classification, ptr
 }
 
-func print6(mask net.IPMask, ip net.IP, ipnet *net.IPNet) {
+func print6(r ipmask.Result) {
 	fmt.Println()
 	fmt.Println("-------------------------------------------------------------------------")
-	if ipnet != nil {
+	if r.HasAddr() {
 		fmt.Println("                        TCP/IP NETWORK INFORMATION                       ")
 	} else {
 		fmt.Println("                      TCP/IP SUBNET MASK EQUIVALENTS                     ")
@@ -233,30 +101,41 @@ func print6(mask net.IPMask, ip net.IP, ipnet *net.IPNet) {
 
 	fmt.Printf("Address Family = ..............: IPv6\n")
 
-	if ip != nil {
-		fmt.Printf("IP Entered = ..................: %s\n", ip.String())
+	if r.HasAddr() {
+		fmt.Printf("IP Entered = ..................: %s\n", r.Addr)
 	}
 
-	fmt.Printf("Prefix = ......................: %s\n", prefix(mask))
-	fmt.Printf("Usable IP Addresses = .........: %s\n", commas(usable(mask)))
+	fmt.Printf("Prefix = ......................: /%d\n", r.Prefix.Bits())
+	fmt.Printf("Netmask = .....................: %s\n", r.Netmask())
+	fmt.Printf("Netmask (expanded) = ..........: %s\n", expand6(r.Netmask()))
+	fmt.Printf("Netmask (hex) = ...............: %s\n", r.NetmaskHex())
+	fmt.Printf("Wildcard Bits = ...............: %s\n", r.Wildcard())
+	fmt.Printf("Wildcard Bits (expanded) = ....: %s\n", expand6(r.Wildcard()))
+	fmt.Printf("Usable IP Addresses = .........: %s\n", commas(r.Usable()))
 
-	if ipnet != nil {
-		first := ipToBigInt(ipnet.IP)
-		last := new(big.Int).Add(first, total(ipnet.Mask))
-		last.Sub(last, b(1))
+	if r.HasAddr() {
+		first, _ := r.FirstUsable()
+		last, _ := r.LastUsable()
 
-		fmt.Printf("First Usable IP Address = .....: %s\n", bigIntToIP(first))
-		fmt.Printf("Last Usable IP Address = ......: %s\n", bigIntToIP(last))
+		fmt.Printf("First Usable IP Address = .....: %s\n", first)
+		fmt.Printf("Last Usable IP Address = ......: %s\n", last)
 	}
 
-	fmt.Println()
+	classification, ptr := classificationLine(r)
+	fmt.Printf("Classification = ..............: %s\n", classification)
+	if r.HasAddr() {
+		fmt.Printf("PTR Name = ....................: %s\n", ptr)
+	} else {
+		fmt.Printf("PTR Zone(s) = .................: %s\n", ptr)
+	}
 
+	fmt.Println()
 }
 
-func print4(mask net.IPMask, ip net.IP, ipnet *net.IPNet) {
+func print4(r ipmask.Result) {
 	fmt.Println()
 	fmt.Println("------------------------------------------------")
-	if ipnet != nil {
+	if r.HasAddr() {
 		fmt.Println("           TCP/IP NETWORK INFORMATION           ")
 	} else {
 		fmt.Println("         TCP/IP SUBNET MASK EQUIVALENTS         ")
@@ -266,124 +145,292 @@ func print4(mask net.IPMask, ip net.IP, ipnet *net.IPNet) {
 
 	fmt.Printf("Address Family = ..............: IPv4\n")
 
-	if ip != nil {
-		fmt.Printf("IP Entered = ..................: %s\n", ip.String())
+	if r.HasAddr() {
+		fmt.Printf("IP Entered = ..................: %s\n", r.Addr)
 	}
 
-	fmt.Printf("CIDR = ........................: %s\n", prefix(mask))
-	fmt.Printf("Netmask = .....................: %s\n", netmask(mask))
-	fmt.Printf("Netmask (hex) = ...............: 0x%s\n", mask.String())
-	fmt.Printf("Wildcard Bits = ...............: %s\n", inverse(mask))
+	fmt.Printf("CIDR = ........................: /%d\n", r.Prefix.Bits())
+	fmt.Printf("Netmask = .....................: %s\n", r.Netmask())
+	fmt.Printf("Netmask (hex) = ...............: %s\n", r.NetmaskHex())
+	fmt.Printf("Wildcard Bits = ...............: %s\n", r.Wildcard())
 
-	if ip == nil {
-		fmt.Printf("Usable IP Addresses = .........: %s\n", commas(usable(mask)))
+	if !r.HasAddr() {
+		fmt.Printf("Usable IP Addresses = .........: %s\n", commas(r.Usable()))
 	}
 
-	if ipnet != nil {
-		n := ipToBigInt(ipnet.IP)
-		broadcast := new(big.Int).Add(n, total(ipnet.Mask))
-		broadcast.Sub(broadcast, b(1))
+	if r.HasAddr() {
+		broadcast, _ := r.Broadcast()
 
-		first := new(big.Int).Add(n, b(1))
-		last := new(big.Int).Sub(broadcast, b(1))
-
-		var firstAddr, lastAddr string
-		if usable(mask).Cmp(b(0)) == 1 {
-			firstAddr = bigIntToIP(first).String()
-			lastAddr = bigIntToIP(last).String()
-		} else {
-			firstAddr = "<none>"
-			lastAddr = "<none>"
+		firstAddr, lastAddr := "<none>", "<none>"
+		if first, ok := r.FirstUsable(); ok {
+			last, _ := r.LastUsable()
+			firstAddr = first.String()
+			lastAddr = last.String()
 		}
 
 		fmt.Println("------------------------------------------------")
-		fmt.Printf("Network Address = .............: %s\n", ipnet.IP.String())
-		fmt.Printf("Broadcast Address = ...........: %s\n", bigIntToIP(broadcast))
-		fmt.Printf("Usable IP Addresses = .........: %s\n", commas(usable(mask)))
+		fmt.Printf("Network Address = .............: %s\n", r.Network())
+		fmt.Printf("Broadcast Address = ...........: %s\n", broadcast)
+		fmt.Printf("Usable IP Addresses = .........: %s\n", commas(r.Usable()))
 		fmt.Printf("First Usable IP Address = .....: %s\n", firstAddr)
 		fmt.Printf("Last Usable IP Address = ......: %s\n", lastAddr)
 	}
 
+	classification, ptr := classificationLine(r)
+	fmt.Printf("Classification = ..............: %s\n", classification)
+	if r.HasAddr() {
+		fmt.Printf("PTR Name = ....................: %s\n", ptr)
+	} else {
+		fmt.Printf("PTR Zone(s) = .................: %s\n", ptr)
+	}
+
 	fmt.Println()
 }
 
-func main() {
-	log.SetFlags(0)
+// splitRow prints one row of a subnet table: the prefix itself, plus
+// (for IPv4) the broadcast address, and the usable range.
+func splitRow(p netip.Prefix, label string) {
+	r := ipmask.Result{Prefix: p}
+
+	first, hasFirst := r.FirstUsable()
+	firstAddr, lastAddr := "<none>", "<none>"
+	if hasFirst {
+		last, _ := r.LastUsable()
+		firstAddr = first.String()
+		lastAddr = last.String()
+	}
 
-	flag.BoolVar(&ipv6, "6", false, "Force IPv6")
-	flag.Parse()
+	if p.Addr().Is4() {
+		broadcast, _ := r.Broadcast()
+		fmt.Printf("%-20s%sbroadcast %-16s usable %s - %s\n", p, label, broadcast, firstAddr, lastAddr)
+	} else {
+		fmt.Printf("%-26s%susable %s - %s\n", p, label, firstAddr, lastAddr)
+	}
+}
 
-	if flag.NArg() != 1 {
-		log.Fatalf("usage: %s [-6] <netmask or ip/netmask>\n", os.Args[0])
+// parseSplitArg turns the -s argument into a new prefix length: a
+// leading "/" names the prefix length directly, otherwise the value
+// is a subnet count and the prefix length is derived from it.
+func parseSplitArg(arg string, parentBits int) (int, error) {
+	if strings.HasPrefix(arg, "/") {
+		n, err := strconv.Atoi(arg[1:])
+		if err != nil {
+			return 0, fmt.Errorf("%s is not a valid prefix length", arg)
+		}
+		return n, nil
 	}
 
-	input := flag.Arg(0)
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s is not a valid subnet count", arg)
+	}
 
-	var mask net.IPMask
-	var ip net.IP
-	var ipnet *net.IPNet
+	return parentBits + bits.Len(uint(n-1)), nil
+}
 
-	switch {
-	case string(input[0]) == "/":
-		var err error
-		mask, err = parsePrefixLength(input)
+func runSplit(input string, v6 bool, arg string) {
+	r, err := ipmask.ParseWithFamily(input, v6)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		if err != nil {
-			log.Fatal(err)
-		}
-	case strings.Contains(input, "/"):
-		var err error
-		ip, ipnet, err = net.ParseCIDR(input)
+	newPrefixLen, err := parseSplitArg(arg, r.Prefix.Bits())
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		if err != nil {
-			log.Fatal(err)
-		}
+	children, err := ipmask.Split(r.Prefix.Masked(), newPrefixLen)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		isV4 := ip.To4() != nil
+	fmt.Println()
+	for _, p := range children {
+		splitRow(p, "")
+	}
+	fmt.Println()
+}
 
-		if isV4 && ipv6 {
-			log.Fatal("Can't force IPv6 for IPv4 address")
-		}
+func parseHostCounts(arg string) ([]int, error) {
+	parts := strings.Split(arg, ",")
 
-		if !isV4 {
-			ipv6 = true
+	counts := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%s is not a valid host count", p)
 		}
+		counts[i] = n
+	}
 
-		mask = ipnet.Mask
-	case strings.Contains(input, "."):
-		if ipv6 {
-			log.Fatalf("%s is an invalid mask for IPv6", input)
-		}
+	return counts, nil
+}
 
-		var err error
-		mask, err = parseMask(input)
+func runVLSM(input string, v6 bool, arg string) {
+	r, err := ipmask.ParseWithFamily(input, v6)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		if err != nil {
-			log.Fatal(err)
+	hostCounts, err := parseHostCounts(arg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	allocations, err := ipmask.VLSM(r.Prefix.Masked(), hostCounts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println()
+	for _, a := range allocations {
+		splitRow(a.Prefix, fmt.Sprintf("hosts %-8d", a.Hosts))
+	}
+	fmt.Println()
+}
+
+func printJSON(r ipmask.Result) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(r.Output()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// isTTY reports whether f is attached to an interactive terminal.
+func isTTY(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// parseContainsArg parses the argument to --contains, which may be
+// a bare address (matched as a single-address /32 or /128 prefix) or
+// a CIDR.
+func parseContainsArg(arg string) (netip.Prefix, error) {
+	if strings.Contains(arg, "/") {
+		return netip.ParsePrefix(arg)
+	}
+
+	addr, err := netip.ParseAddr(arg)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%s is not a valid address or CIDR", arg)
+	}
+
+	bitlen := 32
+	if addr.Is6() {
+		bitlen = 128
+	}
+
+	return netip.PrefixFrom(addr, bitlen), nil
+}
+
+// runBatch reads one CIDR or mask per line from stdin, printing each
+// as a subnet-table row, then aggregates all of them into the
+// minimal covering set of prefixes. If containsArg is non-empty, it
+// also reports which aggregated prefixes cover that address or CIDR.
+func runBatch(v6 bool, containsArg string) {
+	var prefixes []netip.Prefix
+
+	fmt.Println()
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
-	case strings.HasPrefix(input, "0x"):
-		if ipv6 {
-			log.Fatalf("%s is an invalid mask for IPv6", input)
+
+		r, err := ipmask.ParseWithFamily(line, v6)
+		if err != nil {
+			log.Printf("%s: %v", line, err)
+			continue
 		}
 
-		var err error
-		mask, err = parseHex(input)
+		splitRow(r.Prefix.Masked(), "")
+		prefixes = append(prefixes, r.Prefix.Masked())
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	aggregated := ipmask.Aggregate(prefixes)
+
+	fmt.Println()
+	fmt.Println("Aggregated (minimal covering set):")
+	for _, p := range aggregated {
+		fmt.Printf("  %s\n", p)
+	}
+	fmt.Println()
+
+	if containsArg == "" {
+		return
+	}
+
+	target, err := parseContainsArg(containsArg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	covering := ipmask.Covers(aggregated, target)
 
+	fmt.Printf("Covering %s:\n", containsArg)
+	if len(covering) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, p := range covering {
+		fmt.Printf("  %s\n", p)
+	}
+	fmt.Println()
+}
+
+func main() {
+	log.SetFlags(0)
+
+	v6 := flag.Bool("6", false, "Force IPv6")
+	split := flag.String("s", "", "Split the input into equal-size subnets, given as a new prefix length (/26) or a subnet count (4)")
+	vlsm := flag.String("v", "", "VLSM-allocate subnets for a comma-separated list of host counts (100,50,20)")
+	output := flag.String("o", "table", "Output format: table or json")
+	batch := flag.Bool("batch", false, "Read CIDRs/masks from stdin, one per line, and aggregate them")
+	containsArg := flag.String("contains", "", "With -batch, report which aggregated prefixes cover this address or CIDR")
+	flag.Parse()
+
+	if *batch || (flag.NArg() == 0 && !isTTY(os.Stdin)) {
+		runBatch(*v6, *containsArg)
+		return
+	}
+
+	if flag.NArg() != 1 {
+		log.Fatalf("usage: %s [-6] [-o table|json] [-s prefix-or-count] [-v hosts,...] <netmask or ip/netmask>\n", os.Args[0])
+	}
+
+	input := flag.Arg(0)
+
+	switch {
+	case *split != "":
+		runSplit(input, *v6, *split)
+	case *vlsm != "":
+		runVLSM(input, *v6, *vlsm)
+	default:
+		r, err := ipmask.ParseWithFamily(input, *v6)
 		if err != nil {
 			log.Fatal(err)
 		}
-	default:
-		var err error
-		mask, err = parsePrefixLength(fmt.Sprintf("/%s", input))
 
-		if err != nil {
-			log.Fatalf("%s is not a valid subnet mask or wildcard bit mask", input)
+		switch *output {
+		case "table":
+			if r.Prefix.Addr().Is4() {
+				print4(r)
+			} else {
+				print6(r)
+			}
+		case "json":
+			printJSON(r)
+		default:
+			log.Fatalf("unknown output format %q", *output)
 		}
 	}
-
-	if ipv6 {
-		print6(mask, ip, ipnet)
-	} else {
-		print4(mask, ip, ipnet)
-	}
 }
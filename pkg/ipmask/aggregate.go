@@ -0,0 +1,99 @@
+package ipmask
+
+import (
+	"math/big"
+	"net/netip"
+	"sort"
+)
+
+// Aggregate computes the minimal set of CIDR prefixes that together
+// cover exactly the same addresses as prefixes: each input is masked
+// to its network address, prefixes already covered by another are
+// dropped, and adjacent sibling prefixes are repeatedly merged into
+// their shared parent until no more merges are possible.
+func Aggregate(prefixes []netip.Prefix) []netip.Prefix {
+	if len(prefixes) == 0 {
+		return nil
+	}
+
+	masked := make([]netip.Prefix, len(prefixes))
+	for i, p := range prefixes {
+		masked[i] = p.Masked()
+	}
+
+	sort.Slice(masked, func(i, j int) bool {
+		if c := masked[i].Addr().Compare(masked[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return masked[i].Bits() < masked[j].Bits()
+	})
+
+	var deduped []netip.Prefix
+	for _, p := range masked {
+		if n := len(deduped); n > 0 && deduped[n-1].Contains(p.Addr()) {
+			continue
+		}
+		deduped = append(deduped, p)
+	}
+	prefixes = deduped
+
+	for {
+		var next []netip.Prefix
+		merged := false
+
+		for i := 0; i < len(prefixes); i++ {
+			if i+1 < len(prefixes) && canMergeSiblings(prefixes[i], prefixes[i+1]) {
+				next = append(next, netip.PrefixFrom(prefixes[i].Addr(), prefixes[i].Bits()-1))
+				merged = true
+				i++
+				continue
+			}
+			next = append(next, prefixes[i])
+		}
+
+		prefixes = next
+		if !merged {
+			return prefixes
+		}
+	}
+}
+
+// canMergeSiblings reports whether a and b are equal-length sibling
+// prefixes that share a parent: a/n and b/n merge into a/(n-1) when
+// a is the even-numbered sibling (its bit at position bits-n is
+// clear) and b immediately follows it.
+func canMergeSiblings(a, b netip.Prefix) bool {
+	if a.Bits() != b.Bits() || a.Bits() == 0 {
+		return false
+	}
+
+	bitlen := 32
+	if a.Addr().Is6() {
+		bitlen = 128
+	}
+
+	blockSize := new(big.Int).Lsh(big.NewInt(1), uint(bitlen-a.Bits()))
+
+	aInt := addrToBigInt(a.Addr())
+	if new(big.Int).Mod(aInt, new(big.Int).Lsh(blockSize, 1)).Sign() != 0 {
+		return false
+	}
+
+	want := new(big.Int).Add(aInt, blockSize)
+	return want.Cmp(addrToBigInt(b.Addr())) == 0
+}
+
+// Covers returns the subset of prefixes (typically an Aggregate
+// result) that cover target, i.e. those equal to or broader than
+// target that contain its network address.
+func Covers(prefixes []netip.Prefix, target netip.Prefix) []netip.Prefix {
+	var covering []netip.Prefix
+
+	for _, p := range prefixes {
+		if p.Bits() <= target.Bits() && p.Contains(target.Addr()) {
+			covering = append(covering, p)
+		}
+	}
+
+	return covering
+}
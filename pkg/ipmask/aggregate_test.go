@@ -0,0 +1,85 @@
+package ipmask
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func mustPrefixes(t *testing.T, cidrs ...string) []netip.Prefix {
+	t.Helper()
+
+	prefixes := make([]netip.Prefix, len(cidrs))
+	for i, s := range cidrs {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			t.Fatalf("ParsePrefix(%q): %v", s, err)
+		}
+		prefixes[i] = p
+	}
+
+	return prefixes
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{
+			name: "merges siblings",
+			in:   []string{"10.0.0.0/25", "10.0.0.128/25"},
+			want: []string{"10.0.0.0/24"},
+		},
+		{
+			name: "drops contained prefixes",
+			in:   []string{"10.0.0.0/24", "10.0.0.0/25"},
+			want: []string{"10.0.0.0/24"},
+		},
+		{
+			name: "non-adjacent prefixes stay separate",
+			in:   []string{"10.0.0.0/24", "10.0.2.0/24"},
+			want: []string{"10.0.0.0/24", "10.0.2.0/24"},
+		},
+		{
+			name: "cascading merge up to a /22",
+			in:   []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"},
+			want: []string{"10.0.0.0/22"},
+		},
+		{
+			name: "ipv6 siblings merge",
+			in:   []string{"2001:db8::/33", "2001:db8:8000::/33"},
+			want: []string{"2001:db8::/32"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Aggregate(mustPrefixes(t, tt.in...))
+			want := mustPrefixes(t, tt.want...)
+
+			if len(got) != len(want) {
+				t.Fatalf("Aggregate(%v) = %v, want %v", tt.in, got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("Aggregate(%v) = %v, want %v", tt.in, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCovers(t *testing.T) {
+	aggregated := mustPrefixes(t, "10.0.0.0/24", "192.168.1.0/24")
+
+	covering := Covers(aggregated, netip.PrefixFrom(netip.MustParseAddr("10.0.0.5"), 32))
+	if len(covering) != 1 || covering[0].String() != "10.0.0.0/24" {
+		t.Fatalf("Covers = %v, want [10.0.0.0/24]", covering)
+	}
+
+	covering = Covers(aggregated, netip.PrefixFrom(netip.MustParseAddr("172.16.0.1"), 32))
+	if len(covering) != 0 {
+		t.Fatalf("Covers = %v, want none", covering)
+	}
+}
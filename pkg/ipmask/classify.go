@@ -0,0 +1,91 @@
+package ipmask
+
+import "net/netip"
+
+var (
+	documentation4a = netip.MustParsePrefix("192.0.2.0/24")
+	documentation4b = netip.MustParsePrefix("198.51.100.0/24")
+	documentation4c = netip.MustParsePrefix("203.0.113.0/24")
+	documentation6  = netip.MustParsePrefix("2001:db8::/32")
+	cgnat4          = netip.MustParsePrefix("100.64.0.0/10")
+	benchmarking4   = netip.MustParsePrefix("198.18.0.0/15")
+	ula6            = netip.MustParsePrefix("fc00::/7")
+	teredo6         = netip.MustParsePrefix("2001::/32")
+	sixToFour6      = netip.MustParsePrefix("2002::/16")
+)
+
+// Classification reports the scope and special-use status of an
+// address, per the well-known IANA special-purpose registries.
+type Classification struct {
+	Unspecified             bool
+	Loopback                bool
+	Private                 bool
+	LinkLocalUnicast        bool
+	LinkLocalMulticast      bool
+	InterfaceLocalMulticast bool
+	Multicast               bool
+	Documentation           bool
+	CGNAT                   bool // IPv4 100.64.0.0/10 (RFC 6598)
+	Benchmarking            bool // IPv4 198.18.0.0/15 (RFC 2544)
+	ULA                     bool // IPv6 fc00::/7 (RFC 4193)
+	Teredo                  bool // IPv6 2001::/32 (RFC 4380)
+	SixToFour               bool // IPv6 2002::/16 (RFC 3056)
+	IPv4Mapped              bool
+}
+
+// Classify reports the scope and special-use status of addr.
+func Classify(addr netip.Addr) Classification {
+	c := Classification{
+		Unspecified:             addr.IsUnspecified(),
+		Loopback:                addr.IsLoopback(),
+		Private:                 addr.IsPrivate(),
+		LinkLocalUnicast:        addr.IsLinkLocalUnicast(),
+		LinkLocalMulticast:      addr.IsLinkLocalMulticast(),
+		InterfaceLocalMulticast: addr.IsInterfaceLocalMulticast(),
+		Multicast:               addr.IsMulticast(),
+		IPv4Mapped:              addr.Is4In6(),
+	}
+
+	if addr.Is4() || addr.Is4In6() {
+		a := addr.Unmap()
+		c.Documentation = documentation4a.Contains(a) || documentation4b.Contains(a) || documentation4c.Contains(a)
+		c.CGNAT = cgnat4.Contains(a)
+		c.Benchmarking = benchmarking4.Contains(a)
+	} else {
+		c.Documentation = documentation6.Contains(addr)
+		c.ULA = ula6.Contains(addr)
+		c.Teredo = teredo6.Contains(addr)
+		c.SixToFour = sixToFour6.Contains(addr)
+	}
+
+	return c
+}
+
+// Labels returns the names of every special-use category addr
+// matched, in a fixed, stable order.
+func (c Classification) Labels() []string {
+	labels := []string{}
+
+	add := func(match bool, name string) {
+		if match {
+			labels = append(labels, name)
+		}
+	}
+
+	add(c.Unspecified, "Unspecified")
+	add(c.Loopback, "Loopback")
+	add(c.Private, "Private")
+	add(c.LinkLocalUnicast, "Link-Local Unicast")
+	add(c.LinkLocalMulticast, "Link-Local Multicast")
+	add(c.InterfaceLocalMulticast, "Interface-Local Multicast")
+	add(c.Multicast, "Multicast")
+	add(c.Documentation, "Documentation")
+	add(c.CGNAT, "Carrier-Grade NAT")
+	add(c.Benchmarking, "Benchmarking")
+	add(c.ULA, "Unique Local Address")
+	add(c.Teredo, "Teredo")
+	add(c.SixToFour, "6to4")
+	add(c.IPv4Mapped, "IPv4-Mapped")
+
+	return labels
+}
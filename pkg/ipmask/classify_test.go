@@ -0,0 +1,38 @@
+package ipmask
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		addr string
+		want []string
+	}{
+		{"192.168.1.1", []string{"Private"}},
+		{"8.8.8.8", []string{}},
+		{"127.0.0.1", []string{"Loopback"}},
+		{"100.64.0.1", []string{"Carrier-Grade NAT"}},
+		{"198.18.0.1", []string{"Benchmarking"}},
+		{"192.0.2.1", []string{"Documentation"}},
+		{"::1", []string{"Loopback"}},
+		{"fe80::1", []string{"Link-Local Unicast"}},
+		{"fc00::1", []string{"Private", "Unique Local Address"}},
+		{"2001:db8::1", []string{"Documentation"}},
+		{"2001::1", []string{"Teredo"}},
+		{"2002::1", []string{"6to4"}},
+		{"::ffff:1.2.3.4", []string{"IPv4-Mapped"}},
+		{"0.0.0.0", []string{"Unspecified"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			got := Classify(netip.MustParseAddr(tt.addr)).Labels()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Classify(%s).Labels() = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
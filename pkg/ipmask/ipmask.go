@@ -0,0 +1,267 @@
+// Package ipmask parses and formats IP netmasks, CIDR prefixes, and
+// wildcard masks for IPv4 and IPv6, built on top of net/netip.
+package ipmask
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Result is the parsed representation of a mask, prefix length, or
+// address/prefix pair produced by Parse.
+type Result struct {
+	// Prefix holds the address family and prefix length. When the
+	// input didn't include an address (e.g. a bare "/24" or a dotted
+	// netmask), Prefix.Addr() is the unspecified address for the
+	// family and only its bit length is meaningful.
+	Prefix netip.Prefix
+
+	// Addr is the address as entered, unmasked. It is the zero Addr
+	// if the input was a mask or prefix length without an address.
+	Addr netip.Addr
+}
+
+// HasAddr reports whether the input included a specific address, as
+// opposed to a bare mask or prefix length.
+func (r Result) HasAddr() bool {
+	return r.Addr.IsValid()
+}
+
+// bitLen returns 32 for IPv4 and 128 for IPv6.
+func (r Result) bitLen() int {
+	if r.Prefix.Addr().Is4() {
+		return 32
+	}
+	return 128
+}
+
+// Network returns the network address: the entered address or prefix
+// with the host bits cleared.
+func (r Result) Network() netip.Addr {
+	return r.Prefix.Masked().Addr()
+}
+
+// Broadcast returns the last address in the prefix and true, for
+// IPv4 prefixes. It returns the zero Addr and false for IPv6, which
+// has no broadcast concept.
+func (r Result) Broadcast() (netip.Addr, bool) {
+	if !r.Prefix.Addr().Is4() {
+		return netip.Addr{}, false
+	}
+
+	n := addrToBigInt(r.Network())
+	n.Add(n, r.Total())
+	n.Sub(n, big.NewInt(1))
+
+	return bigIntToAddr(n, true), true
+}
+
+// Total returns the number of addresses in the prefix, including the
+// network and broadcast addresses.
+func (r Result) Total() *big.Int {
+	exp := r.bitLen() - r.Prefix.Bits()
+	return new(big.Int).Lsh(big.NewInt(1), uint(exp))
+}
+
+// Usable returns the number of usable host addresses. For IPv4 this
+// is Total minus the network and broadcast addresses (floored at 0);
+// IPv6 has no reserved broadcast address, so Usable equals Total.
+func (r Result) Usable() *big.Int {
+	total := r.Total()
+
+	if !r.Prefix.Addr().Is4() {
+		return total
+	}
+
+	u := new(big.Int).Sub(total, big.NewInt(2))
+	if u.Sign() < 0 {
+		return big.NewInt(0)
+	}
+
+	return u
+}
+
+// FirstUsable returns the first usable address in the prefix and
+// true, or the zero Addr and false if the prefix has no usable
+// addresses.
+func (r Result) FirstUsable() (netip.Addr, bool) {
+	if r.Usable().Sign() == 0 {
+		return netip.Addr{}, false
+	}
+
+	if !r.Prefix.Addr().Is4() {
+		return r.Network(), true
+	}
+
+	n := addrToBigInt(r.Network())
+	n.Add(n, big.NewInt(1))
+
+	return bigIntToAddr(n, true), true
+}
+
+// LastUsable returns the last usable address in the prefix and true,
+// or the zero Addr and false if the prefix has no usable addresses.
+func (r Result) LastUsable() (netip.Addr, bool) {
+	if r.Usable().Sign() == 0 {
+		return netip.Addr{}, false
+	}
+
+	if !r.Prefix.Addr().Is4() {
+		n := addrToBigInt(r.Network())
+		n.Add(n, r.Total())
+		n.Sub(n, big.NewInt(1))
+
+		return bigIntToAddr(n, false), true
+	}
+
+	broadcast, _ := r.Broadcast()
+	n := addrToBigInt(broadcast)
+	n.Sub(n, big.NewInt(1))
+
+	return bigIntToAddr(n, true), true
+}
+
+// maskBytes returns the netmask as a byte slice sized for the
+// result's address family.
+func (r Result) maskBytes() []byte {
+	buf := make([]byte, r.bitLen()/8)
+
+	ones := r.Prefix.Bits()
+	for i := range buf {
+		switch {
+		case ones >= 8:
+			buf[i] = 0xff
+			ones -= 8
+		case ones > 0:
+			buf[i] = byte(0xff << (8 - ones))
+			ones = 0
+		default:
+			buf[i] = 0
+		}
+	}
+
+	return buf
+}
+
+// Netmask returns the prefix length expressed as a netmask address,
+// e.g. /24 becomes 255.255.255.0.
+func (r Result) Netmask() netip.Addr {
+	addr, _ := netip.AddrFromSlice(r.maskBytes())
+	return addr
+}
+
+// NetmaskHex returns Netmask rendered as a 0x-prefixed hex string.
+func (r Result) NetmaskHex() string {
+	return fmt.Sprintf("0x%x", r.maskBytes())
+}
+
+// Wildcard returns the inverse of Netmask, e.g. /24 becomes
+// 0.0.0.255.
+func (r Result) Wildcard() netip.Addr {
+	b := r.maskBytes()
+	for i := range b {
+		b[i] = ^b[i]
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// Parse parses input as an IPv4 or IPv6 mask, prefix length, or
+// address/CIDR pair. Use ParseWithFamily to disambiguate a bare prefix length
+// between 0 and 32 as IPv6.
+func Parse(input string) (Result, error) {
+	return parse(input, false)
+}
+
+// ParseWithFamily is like Parse, but forces IPv6 interpretation of a
+// bare prefix length (e.g. "24" or "/24") that would otherwise be
+// ambiguous between IPv4 and IPv6.
+func ParseWithFamily(input string, v6 bool) (Result, error) {
+	return parse(input, v6)
+}
+
+func parse(input string, forceV6 bool) (Result, error) {
+	if len(input) < 1 {
+		return Result{}, fmt.Errorf("invalid input")
+	}
+
+	switch {
+	case input[0] == '/':
+		return parsePrefixLength(input[1:], forceV6)
+	case strings.Contains(input, "/"):
+		return parseCIDR(input, forceV6)
+	case strings.Contains(input, ":"):
+		return parseColonMask(input)
+	case strings.Contains(input, "."):
+		if forceV6 {
+			return Result{}, fmt.Errorf("%s is an invalid mask for IPv6", input)
+		}
+		return parseDottedMask(input)
+	case strings.HasPrefix(input, "0x"):
+		return parseHexMask(input, forceV6)
+	default:
+		r, err := parsePrefixLength(input, forceV6)
+		if err != nil {
+			return Result{}, fmt.Errorf("%s is not a valid subnet mask or wildcard bit mask", input)
+		}
+		return r, nil
+	}
+}
+
+func parsePrefixLength(input string, forceV6 bool) (Result, error) {
+	if len(input) < 1 {
+		return Result{}, fmt.Errorf("invalid prefix length")
+	}
+
+	n, err := strconv.Atoi(input)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid prefix length")
+	}
+
+	if n < 0 || n > 128 {
+		return Result{}, fmt.Errorf("invalid prefix length (must be between 0 and 128)")
+	}
+
+	v6 := forceV6 || n > 32
+
+	base := netip.IPv4Unspecified()
+	if v6 {
+		base = netip.IPv6Unspecified()
+	}
+
+	return Result{Prefix: netip.PrefixFrom(base, n)}, nil
+}
+
+func parseCIDR(input string, forceV6 bool) (Result, error) {
+	p, err := netip.ParsePrefix(input)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if forceV6 && p.Addr().Is4() {
+		return Result{}, fmt.Errorf("can't force IPv6 for IPv4 address")
+	}
+
+	return Result{Prefix: p, Addr: p.Addr()}, nil
+}
+
+func addrToBigInt(a netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(a.AsSlice())
+}
+
+func bigIntToAddr(n *big.Int, v4 bool) netip.Addr {
+	size := 16
+	if v4 {
+		size = 4
+	}
+
+	buf := make([]byte, size)
+	n.FillBytes(buf)
+
+	addr, _ := netip.AddrFromSlice(buf)
+	return addr
+}
@@ -0,0 +1,178 @@
+package ipmask
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantPrefix string
+		wantAddr   string
+	}{
+		{"v4 CIDR", "192.168.1.0/24", "192.168.1.0/24", "192.168.1.0"},
+		{"v6 CIDR", "2001:db8::/32", "2001:db8::/32", "2001:db8::"},
+		{"bare prefix length defaults to v4", "/24", "0.0.0.0/24", ""},
+		{"dotted netmask", "255.255.255.0", "0.0.0.0/24", ""},
+		{"dotted inverse mask", "0.0.0.255", "0.0.0.0/24", ""},
+		{"colon netmask", "ffff:ffff::", "::/32", ""},
+		{"hex mask", "0xffffff00", "0.0.0.0/24", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+
+			if r.Prefix.String() != tt.wantPrefix {
+				t.Errorf("Parse(%q).Prefix = %s, want %s", tt.input, r.Prefix, tt.wantPrefix)
+			}
+
+			wantHasAddr := tt.wantAddr != ""
+			if r.HasAddr() != wantHasAddr {
+				t.Errorf("Parse(%q).HasAddr() = %v, want %v", tt.input, r.HasAddr(), wantHasAddr)
+			}
+			if wantHasAddr && r.Addr.String() != tt.wantAddr {
+				t.Errorf("Parse(%q).Addr = %s, want %s", tt.input, r.Addr, tt.wantAddr)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty input", ""},
+		{"slash with no digits", "/"},
+		{"negative prefix length", "/-1"},
+		{"prefix length too big", "/129"},
+		{"garbage", "not-a-mask"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.input); err == nil {
+				t.Fatalf("Parse(%q) = nil error, want an error", tt.input)
+			}
+		})
+	}
+}
+
+func TestParseWithFamily(t *testing.T) {
+	t.Run("bare prefix length forced to v6", func(t *testing.T) {
+		r, err := ParseWithFamily("24", true)
+		if err != nil {
+			t.Fatalf("ParseWithFamily(\"24\", true): %v", err)
+		}
+		if r.Prefix.String() != "::/24" {
+			t.Errorf("ParseWithFamily(\"24\", true).Prefix = %s, want ::/24", r.Prefix)
+		}
+	})
+
+	t.Run("forcing v6 on a v4 CIDR is an error", func(t *testing.T) {
+		if _, err := ParseWithFamily("192.168.1.0/24", true); err == nil {
+			t.Fatal("ParseWithFamily(v4 CIDR, true) = nil error, want an error")
+		}
+	})
+
+	t.Run("forcing v6 on a dotted mask is an error", func(t *testing.T) {
+		if _, err := ParseWithFamily("255.255.255.0", true); err == nil {
+			t.Fatal("ParseWithFamily(dotted mask, true) = nil error, want an error")
+		}
+	})
+}
+
+func TestResultArithmetic(t *testing.T) {
+	r, err := Parse("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if got := r.Total().Int64(); got != 256 {
+		t.Errorf("Total() = %d, want 256", got)
+	}
+	if got := r.Usable().Int64(); got != 254 {
+		t.Errorf("Usable() = %d, want 254", got)
+	}
+
+	broadcast, ok := r.Broadcast()
+	if !ok || broadcast.String() != "192.168.1.255" {
+		t.Errorf("Broadcast() = %s, %v, want 192.168.1.255, true", broadcast, ok)
+	}
+
+	first, ok := r.FirstUsable()
+	if !ok || first.String() != "192.168.1.1" {
+		t.Errorf("FirstUsable() = %s, %v, want 192.168.1.1, true", first, ok)
+	}
+
+	last, ok := r.LastUsable()
+	if !ok || last.String() != "192.168.1.254" {
+		t.Errorf("LastUsable() = %s, %v, want 192.168.1.254, true", last, ok)
+	}
+
+	if got := r.Netmask().String(); got != "255.255.255.0" {
+		t.Errorf("Netmask() = %s, want 255.255.255.0", got)
+	}
+	if got := r.NetmaskHex(); got != "0xffffff00" {
+		t.Errorf("NetmaskHex() = %s, want 0xffffff00", got)
+	}
+	if got := r.Wildcard().String(); got != "0.0.0.255" {
+		t.Errorf("Wildcard() = %s, want 0.0.0.255", got)
+	}
+}
+
+func TestResultArithmeticIPv6(t *testing.T) {
+	r, err := Parse("2001:db8::/32")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if _, ok := r.Broadcast(); ok {
+		t.Error("Broadcast() on an IPv6 prefix returned ok=true, want false")
+	}
+
+	if r.Usable().Cmp(r.Total()) != 0 {
+		t.Errorf("Usable() = %s, want it to equal Total() = %s for IPv6", r.Usable(), r.Total())
+	}
+}
+
+func TestResultNoUsableHosts(t *testing.T) {
+	tests := []string{"192.168.1.0/31", "192.168.1.0/32"}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			r, err := Parse(input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", input, err)
+			}
+
+			if r.Usable().Sign() != 0 {
+				t.Fatalf("Usable() = %s, want 0", r.Usable())
+			}
+			if _, ok := r.FirstUsable(); ok {
+				t.Errorf("FirstUsable() ok = true, want false")
+			}
+			if _, ok := r.LastUsable(); ok {
+				t.Errorf("LastUsable() ok = true, want false")
+			}
+		})
+	}
+}
+
+func TestHasAddr(t *testing.T) {
+	withAddr, _ := Parse("192.168.1.0/24")
+	if !withAddr.HasAddr() {
+		t.Error("HasAddr() = false for a parsed CIDR, want true")
+	}
+
+	withoutAddr := Result{Prefix: netip.PrefixFrom(netip.IPv4Unspecified(), 24)}
+	if withoutAddr.HasAddr() {
+		t.Error("HasAddr() = true for a bare prefix, want false")
+	}
+}
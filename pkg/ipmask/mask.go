@@ -0,0 +1,134 @@
+package ipmask
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"net/netip"
+	"regexp"
+	"strconv"
+)
+
+var dottedQuad = regexp.MustCompile(`(\d{1,3}).(\d{1,3}).(\d{1,3}).(\d{1,3})`)
+
+// interpretMask turns raw mask bytes into a Result if they form a
+// contiguous netmask (ones followed by zeros) or its inverse, a
+// wildcard/inverse mask (zeros followed by ones). The address family
+// is inferred from len(raw): 4 bytes for IPv4, 16 for IPv6.
+func interpretMask(raw []byte) (Result, error) {
+	bitlen := len(raw) * 8
+
+	n := new(big.Int).SetBytes(raw)
+	ones := popcount(raw)
+
+	base := netip.IPv4Unspecified()
+	if bitlen == 128 {
+		base = netip.IPv6Unspecified()
+	}
+
+	allOnes := func(k int) *big.Int {
+		m := new(big.Int).Lsh(big.NewInt(1), uint(k))
+		return m.Sub(m, big.NewInt(1))
+	}
+
+	switch {
+	case new(big.Int).Rsh(n, uint(bitlen-ones)).Cmp(allOnes(ones)) == 0:
+		// netmask: top `ones` bits set, the rest clear
+		return Result{Prefix: netip.PrefixFrom(base, ones)}, nil
+	case n.Cmp(allOnes(ones)) == 0:
+		// inverse mask: bottom `ones` bits set, the rest clear
+		return Result{Prefix: netip.PrefixFrom(base, bitlen-ones)}, nil
+	default:
+		return Result{}, fmt.Errorf("invalid netmask or inverse mask")
+	}
+}
+
+func popcount(raw []byte) int {
+	n := 0
+	for _, b := range raw {
+		n += bits.OnesCount8(b)
+	}
+	return n
+}
+
+func getOctet(s string) (byte, error) {
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n > 255 {
+		return 0, fmt.Errorf("invalid octet")
+	}
+
+	return byte(n), nil
+}
+
+// parseDottedMask parses an IPv4 netmask or wildcard mask written in
+// dotted-quad form, e.g. "255.255.255.0" or its inverse
+// "0.0.0.255".
+func parseDottedMask(input string) (Result, error) {
+	match := dottedQuad.FindStringSubmatch(input)
+	if len(match) == 0 {
+		return Result{}, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
+	}
+
+	var octets [4]byte
+	for i, s := range match[1:] {
+		n, err := getOctet(s)
+		if err != nil {
+			return Result{}, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
+		}
+		octets[i] = n
+	}
+
+	r, err := interpretMask(octets[:])
+	if err != nil {
+		return Result{}, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
+	}
+
+	return r, nil
+}
+
+// parseColonMask parses an IPv6 netmask or wildcard mask written in
+// RFC 4291 colon-hex form, e.g. "ffff:ffff:ffff:ffff::" or its
+// inverse "::ffff:ffff:ffff:ffff".
+func parseColonMask(input string) (Result, error) {
+	addr, err := netip.ParseAddr(input)
+	if err != nil || !addr.Is6() {
+		return Result{}, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
+	}
+
+	r, err := interpretMask(addr.AsSlice())
+	if err != nil {
+		return Result{}, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
+	}
+
+	return r, nil
+}
+
+// parseHexMask parses a netmask or wildcard mask written as a
+// 0x-prefixed hex string: 8 hex digits for IPv4, 32 for IPv6.
+func parseHexMask(input string, forceV6 bool) (Result, error) {
+	digits := input[2:]
+
+	switch len(digits) {
+	case 8:
+		if forceV6 {
+			return Result{}, fmt.Errorf("%s is an invalid mask for IPv6", input)
+		}
+	case 32:
+		// IPv6; forceV6 is implied by the digit count.
+	default:
+		return Result{}, fmt.Errorf("%s is not a valid netmask or inverse mask (hex values need 8 or 32 hex digits)", input)
+	}
+
+	raw, err := hex.DecodeString(digits)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s is not a valid netmask or inverse mask: %w", input, err)
+	}
+
+	r, err := interpretMask(raw)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s is not a valid netmask or inverse mask", input)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,143 @@
+package ipmask
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInterpretMask(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []byte
+		want string
+	}{
+		{"v4 all zero", []byte{0, 0, 0, 0}, "/0"},
+		{"v4 all ones", []byte{0xff, 0xff, 0xff, 0xff}, "/32"},
+		{"v4 netmask", []byte{0xff, 0xff, 0xff, 0}, "/24"},
+		{"v4 inverse mask", []byte{0, 0, 0, 0xff}, "/24"},
+		{"v6 netmask", []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, "/32"},
+		{"v6 inverse mask", []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}, "/96"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := interpretMask(tt.raw)
+			if err != nil {
+				t.Fatalf("interpretMask(%v): %v", tt.raw, err)
+			}
+
+			if got := fmt.Sprintf("/%d", r.Prefix.Bits()); got != tt.want {
+				t.Errorf("interpretMask(%v) = %s, want %s", tt.raw, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("non-contiguous is an error", func(t *testing.T) {
+		if _, err := interpretMask([]byte{0xff, 0, 0xff, 0}); err == nil {
+			t.Fatal("interpretMask with a non-contiguous mask = nil error, want an error")
+		}
+	})
+}
+
+func TestParseDottedMask(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"255.255.255.0", 24, false},
+		{"0.0.0.255", 24, false},
+		{"255.255.255.255", 32, false},
+		{"0.0.0.0", 0, false},
+		{"255.0.255.0", 0, true},
+		{"not.an.ip.mask", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := parseDottedMask(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseDottedMask(%q) = nil error, want an error", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseDottedMask(%q): %v", tt.input, err)
+			}
+			if r.Prefix.Bits() != tt.want {
+				t.Errorf("parseDottedMask(%q) = /%d, want /%d", tt.input, r.Prefix.Bits(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseColonMask(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"ffff:ffff:ffff:ffff::", 64, false},
+		{"::ffff:ffff:ffff:ffff", 64, false},
+		{"ffff:ffff::", 32, false},
+		{"::", 0, false},
+		{"2001:db8::1", 0, true},   // not a contiguous mask
+		{"255.255.255.0", 0, true}, // not IPv6
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := parseColonMask(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseColonMask(%q) = nil error, want an error", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseColonMask(%q): %v", tt.input, err)
+			}
+			if r.Prefix.Bits() != tt.want {
+				t.Errorf("parseColonMask(%q) = /%d, want /%d", tt.input, r.Prefix.Bits(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHexMask(t *testing.T) {
+	tests := []struct {
+		input   string
+		forceV6 bool
+		want    int
+		wantErr bool
+	}{
+		{"0xffffff00", false, 24, false},
+		{"0x000000ff", false, 24, false},
+		{"0xffffffff000000000000000000000000", false, 32, false},
+		{"0xffffff00", true, 0, true},  // v4-sized hex isn't valid for -6
+		{"0xdeadbeef", false, 0, true}, // not contiguous
+		{"0xfff", false, 0, true},      // wrong length
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := parseHexMask(tt.input, tt.forceV6)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseHexMask(%q, %v) = nil error, want an error", tt.input, tt.forceV6)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseHexMask(%q, %v): %v", tt.input, tt.forceV6, err)
+			}
+			if r.Prefix.Bits() != tt.want {
+				t.Errorf("parseHexMask(%q, %v) = /%d, want /%d", tt.input, tt.forceV6, r.Prefix.Bits(), tt.want)
+			}
+		})
+	}
+}
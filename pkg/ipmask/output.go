@@ -0,0 +1,77 @@
+package ipmask
+
+import "fmt"
+
+// Output is the stable, serializable representation of a Result. It
+// backs the CLI's -o json mode and is suitable for any other
+// downstream tool that wants to consume ipmask's results as data
+// rather than parse the table output. Fields that don't apply to a
+// given Result (e.g. Broadcast for IPv6, or IPEntered when the input
+// was a bare mask) are left as the zero value, which is omitted from
+// JSON.
+//
+// Total and Usable are decimal strings, not JSON numbers: IPv6
+// counts routinely exceed 2^53 and would lose precision in most
+// JSON number implementations.
+type Output struct {
+	AddressFamily  string   `json:"address_family"`
+	IPEntered      string   `json:"ip_entered,omitempty"`
+	CIDR           string   `json:"cidr"`
+	Netmask        string   `json:"netmask"`
+	NetmaskHex     string   `json:"netmask_hex"`
+	Wildcard       string   `json:"wildcard"`
+	Network        string   `json:"network,omitempty"`
+	Broadcast      string   `json:"broadcast,omitempty"`
+	FirstUsable    string   `json:"first_usable,omitempty"`
+	LastUsable     string   `json:"last_usable,omitempty"`
+	Total          string   `json:"total"`
+	Usable         string   `json:"usable"`
+	Classification []string `json:"classification"`
+	PTRName        string   `json:"ptr_name,omitempty"`
+	PTRZones       []string `json:"ptr_zones,omitempty"`
+}
+
+// Output converts r to its stable, serializable representation.
+func (r Result) Output() Output {
+	family := "IPv4"
+	if !r.Prefix.Addr().Is4() {
+		family = "IPv6"
+	}
+
+	out := Output{
+		AddressFamily: family,
+		CIDR:          fmt.Sprintf("/%d", r.Prefix.Bits()),
+		Netmask:       r.Netmask().String(),
+		NetmaskHex:    r.NetmaskHex(),
+		Wildcard:      r.Wildcard().String(),
+		Total:         r.Total().String(),
+		Usable:        r.Usable().String(),
+	}
+
+	classifyAddr := r.Addr
+	if !r.HasAddr() {
+		classifyAddr = r.Network()
+	}
+	out.Classification = Classify(classifyAddr).Labels()
+
+	if !r.HasAddr() {
+		out.PTRZones = PTRZone(r.Prefix)
+		return out
+	}
+
+	out.IPEntered = r.Addr.String()
+	out.Network = r.Network().String()
+	out.PTRName = PTR(r.Addr)
+
+	if broadcast, ok := r.Broadcast(); ok {
+		out.Broadcast = broadcast.String()
+	}
+
+	if first, ok := r.FirstUsable(); ok {
+		out.FirstUsable = first.String()
+		last, _ := r.LastUsable()
+		out.LastUsable = last.String()
+	}
+
+	return out
+}
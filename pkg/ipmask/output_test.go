@@ -0,0 +1,56 @@
+package ipmask
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestOutputGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"v4_host", "10.0.0.5/24"},
+		{"v4_mask", "/20"},
+		{"v6_host", "2001:db8::1/64"},
+		{"v6_mask", "ffff:ffff:ffff:ffff::"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+
+			got, err := json.MarshalIndent(r.Output(), "", "  ")
+			if err != nil {
+				t.Fatalf("MarshalIndent: %v", err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", tt.name+".json")
+
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+
+			if string(got) != string(want) {
+				t.Errorf("Output for %q doesn't match %s\ngot:\n%s\nwant:\n%s", tt.input, golden, got, want)
+			}
+		})
+	}
+}
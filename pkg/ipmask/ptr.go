@@ -0,0 +1,118 @@
+package ipmask
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// PTR returns the reverse-DNS PTR record name for addr: dotted-octet
+// reversal under in-addr.arpa for IPv4, nibble reversal of the fully
+// expanded address under ip6.arpa for IPv6.
+func PTR(addr netip.Addr) string {
+	if addr.Is4() || addr.Is4In6() {
+		b := addr.Unmap().As4()
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", b[3], b[2], b[1], b[0])
+	}
+
+	b := addr.As16()
+	var sb strings.Builder
+	for i := len(b) - 1; i >= 0; i-- {
+		sb.WriteByte(hexDigits[b[i]&0xf])
+		sb.WriteByte('.')
+		sb.WriteByte(hexDigits[b[i]>>4])
+		sb.WriteByte('.')
+	}
+	sb.WriteString("ip6.arpa.")
+
+	return sb.String()
+}
+
+// PTRZone returns the reverse-DNS zone(s) that cover prefix's
+// network. When the prefix length falls on an octet (IPv4) or
+// nibble (IPv6) boundary, it returns a single zone name; otherwise
+// it returns every zone needed to fully cover the prefix.
+func PTRZone(prefix netip.Prefix) []string {
+	if prefix.Addr().Is4() {
+		return ptrZone4(prefix)
+	}
+	return ptrZone6(prefix)
+}
+
+func ptrZone4(prefix netip.Prefix) []string {
+	n := prefix.Bits()
+	aligned := ((n + 7) / 8) * 8
+	if aligned > 32 {
+		aligned = 32
+	}
+
+	labels := aligned / 8
+	count := 1 << (aligned - n)
+
+	base := prefix.Masked().Addr().As4()
+
+	zones := make([]string, count)
+	for i := 0; i < count; i++ {
+		octets := base
+		if labels > 0 {
+			octets[labels-1] += byte(i)
+		}
+
+		if labels == 0 {
+			zones[i] = "in-addr.arpa."
+			continue
+		}
+
+		parts := make([]string, labels)
+		for j := 0; j < labels; j++ {
+			parts[j] = strconv.Itoa(int(octets[labels-1-j]))
+		}
+		zones[i] = strings.Join(parts, ".") + ".in-addr.arpa."
+	}
+
+	return zones
+}
+
+func ptrZone6(prefix netip.Prefix) []string {
+	n := prefix.Bits()
+	aligned := ((n + 3) / 4) * 4
+	if aligned > 128 {
+		aligned = 128
+	}
+
+	nibbleCount := aligned / 4
+	count := 1 << (aligned - n)
+
+	b := prefix.Masked().Addr().As16()
+	nibbles := make([]byte, 32)
+	for i, by := range b {
+		nibbles[i*2] = by >> 4
+		nibbles[i*2+1] = by & 0xf
+	}
+
+	zones := make([]string, count)
+	for i := 0; i < count; i++ {
+		vals := append([]byte(nil), nibbles...)
+		if nibbleCount > 0 {
+			vals[nibbleCount-1] += byte(i)
+		}
+
+		if nibbleCount == 0 {
+			zones[i] = "ip6.arpa."
+			continue
+		}
+
+		var sb strings.Builder
+		for j := nibbleCount - 1; j >= 0; j-- {
+			sb.WriteByte(hexDigits[vals[j]])
+			sb.WriteByte('.')
+		}
+		sb.WriteString("ip6.arpa.")
+		zones[i] = sb.String()
+	}
+
+	return zones
+}
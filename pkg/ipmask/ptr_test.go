@@ -0,0 +1,73 @@
+package ipmask
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestPTR(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"8.8.8.8", "8.8.8.8.in-addr.arpa."},
+		{"2001:db8::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := PTR(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("PTR(%s) = %s, want %s", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPTRZone(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{
+			name:   "v4 octet-aligned",
+			prefix: "192.168.1.0/24",
+			want:   []string{"1.168.192.in-addr.arpa."},
+		},
+		{
+			name:   "v4 unaligned covers every octet value",
+			prefix: "192.168.0.0/22",
+			want: []string{
+				"0.168.192.in-addr.arpa.",
+				"1.168.192.in-addr.arpa.",
+				"2.168.192.in-addr.arpa.",
+				"3.168.192.in-addr.arpa.",
+			},
+		},
+		{
+			name:   "v6 nibble-aligned",
+			prefix: "2001:db8::/32",
+			want:   []string{"8.b.d.0.1.0.0.2.ip6.arpa."},
+		},
+		{
+			name:   "v6 unaligned covers every nibble value",
+			prefix: "2001:db8::/30",
+			want: []string{
+				"8.b.d.0.1.0.0.2.ip6.arpa.",
+				"9.b.d.0.1.0.0.2.ip6.arpa.",
+				"a.b.d.0.1.0.0.2.ip6.arpa.",
+				"b.b.d.0.1.0.0.2.ip6.arpa.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PTRZone(netip.MustParsePrefix(tt.prefix))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PTRZone(%s) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package ipmask
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"net/netip"
+	"sort"
+)
+
+// maxSplitChildren bounds how many child prefixes Split will
+// materialize. Splitting a large-enough range (e.g. 0.0.0.0/0 into
+// /28s) produces hundreds of millions of children; building a slice
+// that size exhausts memory rather than returning an error, so Split
+// refuses anything past this bound up front.
+const maxSplitChildren = 1 << 20
+
+// Split divides prefix into equal-size child prefixes of length
+// newPrefixLen. It errors if that would produce more than
+// maxSplitChildren subnets.
+//
+// (The toolchain this package targets predates the iter package, so
+// Split returns a slice rather than an iter.Seq[netip.Prefix].)
+func Split(prefix netip.Prefix, newPrefixLen int) ([]netip.Prefix, error) {
+	bitlen := 32
+	if prefix.Addr().Is6() {
+		bitlen = 128
+	}
+
+	if newPrefixLen < prefix.Bits() || newPrefixLen > bitlen {
+		return nil, fmt.Errorf("new prefix length /%d must be between /%d and /%d", newPrefixLen, prefix.Bits(), bitlen)
+	}
+
+	count := new(big.Int).Lsh(big.NewInt(1), uint(newPrefixLen-prefix.Bits()))
+	if !count.IsInt64() || count.Int64() > maxSplitChildren {
+		return nil, fmt.Errorf("splitting %s into /%d would produce %s subnets, more than the limit of %d", prefix, newPrefixLen, count, maxSplitChildren)
+	}
+
+	blockSize := new(big.Int).Lsh(big.NewInt(1), uint(bitlen-newPrefixLen))
+	cursor := addrToBigInt(prefix.Masked().Addr())
+
+	n := count.Int64()
+	children := make([]netip.Prefix, n)
+	for i := int64(0); i < n; i++ {
+		children[i] = netip.PrefixFrom(bigIntToAddr(new(big.Int).Set(cursor), bitlen == 32), newPrefixLen)
+		cursor.Add(cursor, blockSize)
+	}
+
+	return children, nil
+}
+
+// VLSMAllocation is one entry of a VLSM plan: the host count it was
+// requested for, and the prefix allocated to satisfy it.
+type VLSMAllocation struct {
+	Hosts  int
+	Prefix netip.Prefix
+}
+
+// VLSM greedily allocates the smallest subnets of parent that
+// satisfy each requested host count, largest request first, and
+// returns the allocations in the same order as hosts. It errors if
+// the requests don't fit within parent.
+func VLSM(parent netip.Prefix, hosts []int) ([]VLSMAllocation, error) {
+	bitlen := 32
+	if parent.Addr().Is6() {
+		bitlen = 128
+	}
+
+	type request struct {
+		hosts, index int
+	}
+
+	requests := make([]request, len(hosts))
+	for i, h := range hosts {
+		requests[i] = request{hosts: h, index: i}
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].hosts > requests[j].hosts
+	})
+
+	reserved := 0
+	if bitlen == 32 {
+		reserved = 2
+	}
+
+	start := addrToBigInt(parent.Masked().Addr())
+	end := new(big.Int).Add(start, new(big.Int).Lsh(big.NewInt(1), uint(bitlen-parent.Bits())))
+	cursor := new(big.Int).Set(start)
+
+	allocations := make([]VLSMAllocation, len(hosts))
+	for _, req := range requests {
+		hostBits := bits.Len(uint(req.hosts + reserved - 1))
+		newPrefixLen := bitlen - hostBits
+
+		if newPrefixLen < parent.Bits() {
+			return nil, fmt.Errorf("%d hosts don't fit in %s", req.hosts, parent)
+		}
+
+		blockSize := new(big.Int).Lsh(big.NewInt(1), uint(bitlen-newPrefixLen))
+		next := new(big.Int).Add(cursor, blockSize)
+		if next.Cmp(end) > 0 {
+			return nil, fmt.Errorf("VLSM requests overflow %s", parent)
+		}
+
+		allocations[req.index] = VLSMAllocation{
+			Hosts:  req.hosts,
+			Prefix: netip.PrefixFrom(bigIntToAddr(new(big.Int).Set(cursor), bitlen == 32), newPrefixLen),
+		}
+		cursor = next
+	}
+
+	return allocations, nil
+}
@@ -0,0 +1,115 @@
+package ipmask
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name         string
+		prefix       string
+		newPrefixLen int
+		want         []string
+	}{
+		{
+			name:         "v4 into four",
+			prefix:       "192.168.0.0/24",
+			newPrefixLen: 26,
+			want:         []string{"192.168.0.0/26", "192.168.0.64/26", "192.168.0.128/26", "192.168.0.192/26"},
+		},
+		{
+			name:         "same prefix length is a single child",
+			prefix:       "10.0.0.0/24",
+			newPrefixLen: 24,
+			want:         []string{"10.0.0.0/24"},
+		},
+		{
+			name:         "v6 into two",
+			prefix:       "2001:db8::/32",
+			newPrefixLen: 33,
+			want:         []string{"2001:db8::/33", "2001:db8:8000::/33"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix := netip.MustParsePrefix(tt.prefix)
+
+			got, err := Split(prefix, tt.newPrefixLen)
+			if err != nil {
+				t.Fatalf("Split(%s, /%d): %v", tt.prefix, tt.newPrefixLen, err)
+			}
+
+			want := make([]netip.Prefix, len(tt.want))
+			for i, s := range tt.want {
+				want[i] = netip.MustParsePrefix(s)
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("Split(%s, /%d) = %v, want %v", tt.prefix, tt.newPrefixLen, got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("Split(%s, /%d)[%d] = %s, want %s", tt.prefix, tt.newPrefixLen, i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		prefix       string
+		newPrefixLen int
+	}{
+		{"shorter than parent", "10.0.0.0/24", 16},
+		{"past address width", "10.0.0.0/24", 33},
+		{"explodes past the child-count limit", "0.0.0.0/0", 28},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix := netip.MustParsePrefix(tt.prefix)
+
+			if _, err := Split(prefix, tt.newPrefixLen); err == nil {
+				t.Fatalf("Split(%s, /%d) = nil error, want an error", tt.prefix, tt.newPrefixLen)
+			}
+		})
+	}
+}
+
+func TestVLSM(t *testing.T) {
+	parent := netip.MustParsePrefix("192.168.0.0/24")
+
+	allocations, err := VLSM(parent, []int{60, 14, 2})
+	if err != nil {
+		t.Fatalf("VLSM: %v", err)
+	}
+
+	want := []string{"192.168.0.0/26", "192.168.0.64/28", "192.168.0.80/30"}
+	if len(allocations) != len(want) {
+		t.Fatalf("VLSM = %v, want %v", allocations, want)
+	}
+	for i, a := range allocations {
+		if a.Prefix.String() != want[i] {
+			t.Fatalf("VLSM[%d] = %s (hosts %d), want %s", i, a.Prefix, a.Hosts, want[i])
+		}
+		if a.Hosts != []int{60, 14, 2}[i] {
+			t.Fatalf("VLSM[%d].Hosts = %d, want %d", i, a.Hosts, []int{60, 14, 2}[i])
+		}
+	}
+}
+
+func TestVLSMOverflow(t *testing.T) {
+	parent := netip.MustParsePrefix("192.168.0.0/24")
+
+	if _, err := VLSM(parent, []int{1000000}); err == nil {
+		t.Fatal("VLSM with a request that doesn't fit = nil error, want an error")
+	}
+
+	if _, err := VLSM(parent, []int{200, 200}); err == nil {
+		t.Fatal("VLSM requests overflowing the parent = nil error, want an error")
+	}
+}